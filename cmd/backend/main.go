@@ -14,6 +14,12 @@ func main() {
 	flag.Parse()
 
 	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "healthy")
+	})
+
 	// Handler for the root path
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		// Get hostname for identification
@@ -41,20 +47,14 @@ func main() {
 				fmt.Fprintf(w, "  %s: %s\n", name, value)
 			}
 		}
+	})
 
-		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			fmt.Fprintf(w, "healthy")
-		})
-
-		server := http.Server{
-			Addr:    fmt.Sprintf(":%d", *port),
-			Handler: mux,
-		}
-
-		if err := server.ListenAndServe(); err != nil {
-			log.Fatal(err)
-		}
+	server := http.Server{
+		Addr:    fmt.Sprintf(":%d", *port),
+		Handler: mux,
+	}
 
-	})
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatal(err)
+	}
 }
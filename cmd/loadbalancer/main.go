@@ -5,36 +5,124 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/go-load-balancer/pkg/loadbalancer"
+	"golang.org/x/net/http2"
 )
 
 func main() {
-	port := flag.Int("port", 8080, "Port to serve on")
+	port := flag.Int("port", 8080, "Port to serve on (overridden by listen_addr in -config)")
+	adminPort := flag.Int("admin-port", 9090, "Port to serve Prometheus metrics on (overridden by admin_addr in -config)")
+	configPath := flag.String("config", "", "Path to a JSON or YAML config file")
 	flag.Parse()
 
-	serverList := []string{
-		"http://localhost:8081",
-		"http://localhost:8082",
-		"http://localhost:8083",
-	}
+	addr := fmt.Sprintf(":%d", *port)
+	adminAddr := fmt.Sprintf(":%d", *adminPort)
+
+	var (
+		lb                        *loadbalancer.LoadBalancer
+		cfg                       *loadbalancer.Config
+		readTimeout, writeTimeout time.Duration
+		err                       error
+	)
 
-	lb, err := loadbalancer.NewLoadBalancer(serverList)
+	if *configPath == "" {
+		serverList := []string{
+			"http://localhost:8081",
+			"http://localhost:8082",
+			"http://localhost:8083",
+		}
+		lb, err = loadbalancer.NewLoadBalancer(serverList)
+	} else {
+		cfg, err = loadbalancer.LoadConfig(*configPath)
+		if err == nil {
+			if cfg.ListenAddr != "" {
+				addr = cfg.ListenAddr
+			}
+			if cfg.AdminAddr != "" {
+				adminAddr = cfg.AdminAddr
+			}
+			readTimeout, writeTimeout, err = cfg.ServerTimeouts()
+		}
+		if err == nil {
+			lb, err = loadbalancer.NewLoadBalancerFromConfig(cfg)
+		}
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Start health checker in background
-	go lb.HealthCheckPeriodically(2 * time.Minute)
+	// Start active health checking in the background; passive ejection
+	// runs inside ServeHTTP without a separate start
+	lb.StartHealthChecker()
+
+	go func() {
+		log.Printf("Metrics serving on %s/metrics", adminAddr)
+		if err := loadbalancer.StartMetricsServer(adminAddr); err != nil {
+			log.Printf("Metrics server stopped: %v", err)
+		}
+	}()
+
+	if *configPath != "" {
+		watchConfigReload(lb, *configPath)
+	}
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      lb,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+	}
+
+	var certFile, keyFile string
+	var useTLS bool
+	if cfg != nil {
+		certFile, keyFile, useTLS, err = cfg.ConfigureTLS(server)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	server := http.Server{
-		Addr:    fmt.Sprintf(":%d", *port),
-		Handler: lb,
+	if useTLS {
+		if err := http2.ConfigureServer(server, nil); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Load balancer serving TLS on %s", addr)
+		if err := server.ListenAndServeTLS(certFile, keyFile); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	log.Printf("Load balancer serving on port %d", *port)
+	log.Printf("Load balancer serving on %s", addr)
 	if err := server.ListenAndServe(); err != nil {
 		log.Fatal(err)
 	}
-}
\ No newline at end of file
+}
+
+// watchConfigReload reparses configPath and atomically swaps lb's backend
+// set whenever the process receives SIGHUP, so operators can add or remove
+// upstreams without dropping traffic or restarting.
+func watchConfigReload(lb *loadbalancer.LoadBalancer, configPath string) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		for range sighup {
+			cfg, err := loadbalancer.LoadConfig(configPath)
+			if err != nil {
+				log.Printf("Config reload: failed to load %s: %v", configPath, err)
+				continue
+			}
+			if err := lb.Reload(cfg); err != nil {
+				log.Printf("Config reload: failed to apply %s: %v", configPath, err)
+				continue
+			}
+			log.Printf("Config reload: applied %s", configPath)
+		}
+	}()
+}
@@ -0,0 +1,44 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// accessLogEntry is the JSON shape emitted once per request by logAccess.
+type accessLogEntry struct {
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Backend    string  `json:"backend,omitempty"`
+	Status     int     `json:"status"`
+	UpstreamMs float64 `json:"upstream_ms"`
+	TotalMs    float64 `json:"total_ms"`
+	Retries    int     `json:"retries"`
+	ClientIP   string  `json:"client_ip"`
+}
+
+// logAccess emits a single structured JSON access log line for a completed
+// request. retries is the number of attempts beyond the first.
+func logAccess(r *http.Request, backend *Backend, status int, upstreamLatency, totalLatency time.Duration, retries int) {
+	entry := accessLogEntry{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     status,
+		UpstreamMs: float64(upstreamLatency.Microseconds()) / 1000,
+		TotalMs:    float64(totalLatency.Microseconds()) / 1000,
+		Retries:    retries,
+		ClientIP:   clientKey(r),
+	}
+	if backend != nil {
+		entry.Backend = backend.URL.String()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("access log: marshal failed: %v", err)
+		return
+	}
+	log.Println(string(data))
+}
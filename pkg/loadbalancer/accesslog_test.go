@@ -0,0 +1,70 @@
+package loadbalancer
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLogAccessJSONShape(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	backend := mustBackend(t, "http://backend.example")
+	r := httptest.NewRequest("GET", "/foo", nil)
+	r.RemoteAddr = "203.0.113.7:54321"
+
+	logAccess(r, backend, 200, 12*time.Millisecond, 15*time.Millisecond, 1)
+
+	var entry accessLogEntry
+	line := bytes.TrimSpace(buf.Bytes())
+	line = line[bytes.IndexByte(line, '{'):]
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatalf("unmarshaling logged line %q: %v", line, err)
+	}
+
+	if entry.Method != "GET" {
+		t.Errorf("Method = %q, want %q", entry.Method, "GET")
+	}
+	if entry.Path != "/foo" {
+		t.Errorf("Path = %q, want %q", entry.Path, "/foo")
+	}
+	if entry.Backend != "http://backend.example" {
+		t.Errorf("Backend = %q, want %q", entry.Backend, "http://backend.example")
+	}
+	if entry.Status != 200 {
+		t.Errorf("Status = %d, want 200", entry.Status)
+	}
+	if entry.Retries != 1 {
+		t.Errorf("Retries = %d, want 1", entry.Retries)
+	}
+	if entry.ClientIP != "203.0.113.7" {
+		t.Errorf("ClientIP = %q, want %q", entry.ClientIP, "203.0.113.7")
+	}
+}
+
+func TestLogAccessOmitsBackendWhenNil(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	r := httptest.NewRequest("GET", "/", nil)
+
+	logAccess(r, nil, 503, 0, 0, 0)
+
+	var entry accessLogEntry
+	line := bytes.TrimSpace(buf.Bytes())
+	line = line[bytes.IndexByte(line, '{'):]
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatalf("unmarshaling logged line %q: %v", line, err)
+	}
+
+	if entry.Backend != "" {
+		t.Errorf("Backend = %q, want empty when no backend was reached", entry.Backend)
+	}
+}
@@ -0,0 +1,243 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HealthCheckFileConfig is the on-disk representation of HealthCheckConfig.
+// Durations are strings (e.g. "10s") so they read naturally in JSON/YAML;
+// zero values fall back to DefaultHealthCheckConfig.
+type HealthCheckFileConfig struct {
+	Path               string `json:"path,omitempty" yaml:"path,omitempty"`
+	Interval           string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Timeout            string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	ExpectedStatus     int    `json:"expected_status,omitempty" yaml:"expected_status,omitempty"`
+	HealthyThreshold   int    `json:"healthy_threshold,omitempty" yaml:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int    `json:"unhealthy_threshold,omitempty" yaml:"unhealthy_threshold,omitempty"`
+	MaxFails           int    `json:"max_fails,omitempty" yaml:"max_fails,omitempty"`
+	FailTimeout        string `json:"fail_timeout,omitempty" yaml:"fail_timeout,omitempty"`
+}
+
+// TLSConfig holds frontend TLS settings. Either CertFile/KeyFile or
+// AutocertHosts must be set to terminate TLS; a TLS block that sets neither
+// is rejected by ConfigureTLS. Without a TLS block the frontend serves plain
+// HTTP.
+type TLSConfig struct {
+	CertFile string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	// AutocertHosts, if set, fetches and renews certificates from Let's
+	// Encrypt (via ACME) for these hostnames instead of using CertFile/KeyFile.
+	AutocertHosts []string `json:"autocert_hosts,omitempty" yaml:"autocert_hosts,omitempty"`
+	// AutocertCacheDir stores issued certificates across restarts. Defaults
+	// to the current directory.
+	AutocertCacheDir string `json:"autocert_cache_dir,omitempty" yaml:"autocert_cache_dir,omitempty"`
+}
+
+// TransportFileConfig is the on-disk representation of TransportConfig, the
+// tuning for the single http.Transport shared across every backend.
+type TransportFileConfig struct {
+	MaxIdleConnsPerHost int    `json:"max_idle_conns_per_host,omitempty" yaml:"max_idle_conns_per_host,omitempty"`
+	DialTimeout         string `json:"dial_timeout,omitempty" yaml:"dial_timeout,omitempty"`
+	KeepAlive           string `json:"keep_alive,omitempty" yaml:"keep_alive,omitempty"`
+	// InsecureSkipVerify disables certificate verification for https://
+	// backends; only meant for trusted networks.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+	// CACertFile is a PEM bundle trusted for verifying https:// backend
+	// certificates, in addition to the system roots.
+	CACertFile string `json:"ca_cert_file,omitempty" yaml:"ca_cert_file,omitempty"`
+}
+
+// Config is the on-disk shape of a load balancer deployment, loadable from
+// either JSON or YAML via LoadConfig.
+type Config struct {
+	ListenAddr     string                `json:"listen_addr,omitempty" yaml:"listen_addr,omitempty"`
+	AdminAddr      string                `json:"admin_addr,omitempty" yaml:"admin_addr,omitempty"`
+	Backends       []string              `json:"backends" yaml:"backends"`
+	Strategy       string                `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	HealthCheck    HealthCheckFileConfig `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+	ReadTimeout    string                `json:"read_timeout,omitempty" yaml:"read_timeout,omitempty"`
+	WriteTimeout   string                `json:"write_timeout,omitempty" yaml:"write_timeout,omitempty"`
+	MaxRetries     int                   `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+	RequestTimeout string                `json:"request_timeout,omitempty" yaml:"request_timeout,omitempty"`
+	TLS            *TLSConfig            `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Transport      TransportFileConfig   `json:"transport,omitempty" yaml:"transport,omitempty"`
+}
+
+// LoadConfig reads and parses a Config from path, choosing JSON or YAML
+// based on the file extension.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	cfg := &Config{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing yaml config %s: %w", path, err)
+		}
+	case ".json":
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing json config %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config extension %q (want .json, .yaml or .yml)", ext)
+	}
+
+	return cfg, nil
+}
+
+// HealthCheckConfig builds the runtime HealthCheckConfig described by this
+// Config, starting from DefaultHealthCheckConfig and overriding only the
+// fields the file set.
+func (c *Config) HealthCheckConfig() (HealthCheckConfig, error) {
+	hc := DefaultHealthCheckConfig()
+	fc := c.HealthCheck
+
+	if fc.Path != "" {
+		hc.HealthPath = fc.Path
+	}
+	if fc.ExpectedStatus != 0 {
+		hc.ExpectedStatus = fc.ExpectedStatus
+	}
+	if fc.HealthyThreshold != 0 {
+		hc.HealthyThreshold = fc.HealthyThreshold
+	}
+	if fc.UnhealthyThreshold != 0 {
+		hc.UnhealthyThreshold = fc.UnhealthyThreshold
+	}
+	if fc.MaxFails != 0 {
+		hc.MaxFails = fc.MaxFails
+	}
+
+	var err error
+	if hc.Interval, err = parseDurationOr(fc.Interval, hc.Interval); err != nil {
+		return hc, fmt.Errorf("health_check.interval: %w", err)
+	}
+	if hc.Timeout, err = parseDurationOr(fc.Timeout, hc.Timeout); err != nil {
+		return hc, fmt.Errorf("health_check.timeout: %w", err)
+	}
+	if hc.FailTimeout, err = parseDurationOr(fc.FailTimeout, hc.FailTimeout); err != nil {
+		return hc, fmt.Errorf("health_check.fail_timeout: %w", err)
+	}
+
+	return hc, nil
+}
+
+// StrategyFromName resolves the configured strategy name to a Strategy,
+// defaulting to RoundRobin when unset.
+func (c *Config) StrategyFromName() (Strategy, error) {
+	switch strings.ToLower(strings.ReplaceAll(c.Strategy, "-", "_")) {
+	case "", "round_robin":
+		return NewRoundRobin(), nil
+	case "least_connections":
+		return LeastConnections{}, nil
+	case "random":
+		return Random{}, nil
+	case "ip_hash":
+		return IPHash{}, nil
+	case "p2c_ewma":
+		return P2CEWMA{}, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", c.Strategy)
+	}
+}
+
+// RetryConfig builds the runtime RetryConfig described by this Config,
+// starting from DefaultRetryConfig and overriding only the fields the file
+// set.
+func (c *Config) RetryConfig() (RetryConfig, error) {
+	rc := DefaultRetryConfig()
+	if c.MaxRetries != 0 {
+		rc.MaxRetries = c.MaxRetries
+	}
+
+	var err error
+	if rc.RequestTimeout, err = parseDurationOr(c.RequestTimeout, rc.RequestTimeout); err != nil {
+		return rc, fmt.Errorf("request_timeout: %w", err)
+	}
+
+	return rc, nil
+}
+
+// TransportConfig builds the runtime TransportConfig described by this
+// Config, starting from DefaultTransportConfig and overriding only the
+// fields the file set.
+func (c *Config) TransportConfig() (TransportConfig, error) {
+	tc := DefaultTransportConfig()
+	fc := c.Transport
+
+	if fc.MaxIdleConnsPerHost != 0 {
+		tc.MaxIdleConnsPerHost = fc.MaxIdleConnsPerHost
+	}
+	tc.InsecureSkipVerify = fc.InsecureSkipVerify
+	tc.CACertFile = fc.CACertFile
+
+	var err error
+	if tc.DialTimeout, err = parseDurationOr(fc.DialTimeout, tc.DialTimeout); err != nil {
+		return tc, fmt.Errorf("transport.dial_timeout: %w", err)
+	}
+	if tc.KeepAlive, err = parseDurationOr(fc.KeepAlive, tc.KeepAlive); err != nil {
+		return tc, fmt.Errorf("transport.keep_alive: %w", err)
+	}
+
+	return tc, nil
+}
+
+// ServerTimeouts parses the configured read/write timeouts, defaulting to
+// zero (no timeout) when unset.
+func (c *Config) ServerTimeouts() (read, write time.Duration, err error) {
+	if read, err = parseDurationOr(c.ReadTimeout, 0); err != nil {
+		return 0, 0, fmt.Errorf("read_timeout: %w", err)
+	}
+	if write, err = parseDurationOr(c.WriteTimeout, 0); err != nil {
+		return 0, 0, fmt.Errorf("write_timeout: %w", err)
+	}
+	return read, write, nil
+}
+
+// parseDurationOr parses s as a time.Duration, returning fallback when s is
+// empty.
+func parseDurationOr(s string, fallback time.Duration) (time.Duration, error) {
+	if s == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// NewLoadBalancerFromConfig builds a LoadBalancer from a parsed Config.
+func NewLoadBalancerFromConfig(cfg *Config) (*LoadBalancer, error) {
+	healthConfig, err := cfg.HealthCheckConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := cfg.StrategyFromName()
+	if err != nil {
+		return nil, err
+	}
+
+	retryConfig, err := cfg.RetryConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transportConfig, err := cfg.TransportConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport, err := NewTransport(transportConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLoadBalancer(cfg.Backends, WithStrategy(strategy), WithHealthCheckConfig(healthConfig), WithRetryConfig(retryConfig), WithTransport(transport))
+}
@@ -0,0 +1,68 @@
+package loadbalancer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"backends":["http://a","http://b"],"strategy":"least_connections","max_retries":5}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Backends) != 2 || cfg.Backends[0] != "http://a" || cfg.Backends[1] != "http://b" {
+		t.Fatalf("Backends = %v, want [http://a http://b]", cfg.Backends)
+	}
+	if cfg.Strategy != "least_connections" {
+		t.Fatalf("Strategy = %q, want %q", cfg.Strategy, "least_connections")
+	}
+	if cfg.MaxRetries != 5 {
+		t.Fatalf("MaxRetries = %d, want 5", cfg.MaxRetries)
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "backends:\n  - http://a\n  - http://b\nstrategy: ip_hash\nmax_retries: 2\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Backends) != 2 || cfg.Backends[0] != "http://a" || cfg.Backends[1] != "http://b" {
+		t.Fatalf("Backends = %v, want [http://a http://b]", cfg.Backends)
+	}
+	if cfg.Strategy != "ip_hash" {
+		t.Fatalf("Strategy = %q, want %q", cfg.Strategy, "ip_hash")
+	}
+	if cfg.MaxRetries != 2 {
+		t.Fatalf("MaxRetries = %d, want 2", cfg.MaxRetries)
+	}
+}
+
+func TestLoadConfigUnsupportedExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("backends = []"), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("LoadConfig() error = nil, want an error for an unsupported extension")
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("LoadConfig() error = nil, want an error for a missing file")
+	}
+}
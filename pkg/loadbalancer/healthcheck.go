@@ -0,0 +1,269 @@
+package loadbalancer
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// HealthCheckConfig controls both the active health-check prober and the
+// passive ejection that reacts to live traffic failures.
+type HealthCheckConfig struct {
+	// HealthPath is the path probed on each backend, e.g. "/health".
+	HealthPath string
+	// Interval is the time between active probes of a single backend.
+	Interval time.Duration
+	// Timeout bounds a single active probe request.
+	Timeout time.Duration
+	// ExpectedStatus is the HTTP status code a healthy backend must return.
+	ExpectedStatus int
+	// BodyPattern, if set, must match the probe response body for the
+	// backend to be considered healthy.
+	BodyPattern *regexp.Regexp
+	// HealthyThreshold is the number of consecutive successful probes
+	// required before a dead backend is marked alive again.
+	HealthyThreshold int
+	// UnhealthyThreshold is the number of consecutive failed probes
+	// required before an alive backend is marked dead.
+	UnhealthyThreshold int
+	// MaxFails is the number of passive (live-traffic) failures within
+	// FailTimeout that ejects a backend.
+	MaxFails int
+	// FailTimeout is both the passive failure-counting window and how
+	// long an ejected backend stays out of rotation before it's eligible
+	// again.
+	FailTimeout time.Duration
+}
+
+// DefaultHealthCheckConfig returns the health-check settings used when a
+// LoadBalancer is constructed without an explicit HealthCheckConfig.
+func DefaultHealthCheckConfig() HealthCheckConfig {
+	return HealthCheckConfig{
+		HealthPath:         "/health",
+		Interval:           10 * time.Second,
+		Timeout:            2 * time.Second,
+		ExpectedStatus:     http.StatusOK,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 3,
+		MaxFails:           3,
+		FailTimeout:        30 * time.Second,
+	}
+}
+
+// HealthChecker actively probes a set of backends on their own ticker and
+// flips Backend.Alive once a backend crosses its healthy/unhealthy
+// consecutive-result threshold. Each backend gets its own stop channel so a
+// config reload can stop watching a single removed backend without
+// disturbing the others. config and client are read and replaced under mux
+// so UpdateConfig can safely change them while probe loops are running.
+type HealthChecker struct {
+	mux    sync.Mutex
+	config HealthCheckConfig
+	client *http.Client
+	stops  map[*Backend]chan struct{}
+}
+
+// NewHealthChecker builds a HealthChecker from the given config.
+func NewHealthChecker(config HealthCheckConfig) *HealthChecker {
+	return &HealthChecker{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		stops:  make(map[*Backend]chan struct{}),
+	}
+}
+
+// Start begins probing every backend in the set that isn't already being
+// watched. It returns immediately.
+func (hc *HealthChecker) Start(backends []*Backend) {
+	for _, b := range backends {
+		hc.watch(b)
+	}
+}
+
+func (hc *HealthChecker) watch(b *Backend) {
+	hc.mux.Lock()
+	if _, ok := hc.stops[b]; ok {
+		hc.mux.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	hc.stops[b] = stop
+	hc.mux.Unlock()
+
+	go hc.run(b, stop)
+}
+
+// StopBackend stops probing a single backend, used when it's dropped from
+// rotation by a config reload.
+func (hc *HealthChecker) StopBackend(b *Backend) {
+	hc.mux.Lock()
+	defer hc.mux.Unlock()
+	if stop, ok := hc.stops[b]; ok {
+		close(stop)
+		delete(hc.stops, b)
+	}
+}
+
+// UpdateConfig swaps in newConfig and restarts every currently-watched
+// backend's probe loop against it, so a changed Interval, HealthPath,
+// Timeout, or threshold from a config reload takes effect immediately
+// instead of only applying to backends added after the reload.
+func (hc *HealthChecker) UpdateConfig(newConfig HealthCheckConfig) {
+	hc.mux.Lock()
+	hc.config = newConfig
+	hc.client = &http.Client{Timeout: newConfig.Timeout}
+	backends := make([]*Backend, 0, len(hc.stops))
+	for b, stop := range hc.stops {
+		close(stop)
+		backends = append(backends, b)
+	}
+	hc.stops = make(map[*Backend]chan struct{})
+	hc.mux.Unlock()
+
+	for _, b := range backends {
+		hc.watch(b)
+	}
+}
+
+func (hc *HealthChecker) getConfig() HealthCheckConfig {
+	hc.mux.Lock()
+	defer hc.mux.Unlock()
+	return hc.config
+}
+
+func (hc *HealthChecker) getClient() *http.Client {
+	hc.mux.Lock()
+	defer hc.mux.Unlock()
+	return hc.client
+}
+
+// Stop halts probing for every backend currently being watched.
+func (hc *HealthChecker) Stop() {
+	hc.mux.Lock()
+	defer hc.mux.Unlock()
+	for b, stop := range hc.stops {
+		close(stop)
+		delete(hc.stops, b)
+	}
+}
+
+func (hc *HealthChecker) run(b *Backend, stop chan struct{}) {
+	ticker := time.NewTicker(hc.getConfig().Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			hc.check(b)
+		}
+	}
+}
+
+func (hc *HealthChecker) check(b *Backend) {
+	cfg := hc.getConfig()
+	healthy := hc.probe(b, cfg)
+	b.recordActiveCheck(healthy, cfg.HealthyThreshold, cfg.UnhealthyThreshold)
+}
+
+// probe issues a single HTTP GET against the backend's health path and
+// validates the status code (and body, if a BodyPattern is configured).
+func (hc *HealthChecker) probe(b *Backend, cfg HealthCheckConfig) bool {
+	target := *b.URL
+	target.Path = cfg.HealthPath
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		log.Printf("Health check request build failed for %s: %v", b.URL, err)
+		return false
+	}
+
+	resp, err := hc.getClient().Do(req)
+	if err != nil {
+		log.Printf("Health check failed for %s: %v", b.URL, err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != cfg.ExpectedStatus {
+		return false
+	}
+
+	if cfg.BodyPattern == nil {
+		return true
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false
+	}
+	return cfg.BodyPattern.Match(body)
+}
+
+// recordActiveCheck folds the result of one active probe into the
+// backend's consecutive success/failure counters and flips Alive once a
+// threshold is crossed.
+func (b *Backend) recordActiveCheck(healthy bool, healthyThreshold, unhealthyThreshold int) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if healthy {
+		b.consecutiveFailures = 0
+		b.consecutiveSuccesses++
+		if !b.Alive && b.consecutiveSuccesses >= healthyThreshold {
+			b.Alive = true
+			backendHealthy.WithLabelValues(b.URL.String()).Set(1)
+			log.Printf("Backend %s passed %d consecutive health checks, marking alive", b.URL, b.consecutiveSuccesses)
+		}
+		return
+	}
+
+	b.consecutiveSuccesses = 0
+	b.consecutiveFailures++
+	if b.Alive && b.consecutiveFailures >= unhealthyThreshold {
+		b.Alive = false
+		backendHealthy.WithLabelValues(b.URL.String()).Set(0)
+		log.Printf("Backend %s failed %d consecutive health checks, marking dead", b.URL, b.consecutiveFailures)
+	}
+}
+
+// RecordPassiveFailure counts a live-traffic failure (proxy error or 5xx
+// response) against the backend and ejects it once MaxFails is reached
+// within FailTimeout. An ejected backend is excluded from selection until
+// FailTimeout elapses.
+func (b *Backend) RecordPassiveFailure(maxFails int, failTimeout time.Duration) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	now := time.Now()
+	if b.failWindowStart.IsZero() || now.Sub(b.failWindowStart) > failTimeout {
+		b.failWindowStart = now
+		b.failCount = 0
+	}
+	b.failCount++
+
+	if b.failCount >= maxFails {
+		b.ejectedUntil = now.Add(failTimeout)
+		log.Printf("Backend %s ejected for %s after %d failures", b.URL, failTimeout, b.failCount)
+	}
+}
+
+// RecordPassiveSuccess resets the passive failure window after a
+// successful live request.
+func (b *Backend) RecordPassiveSuccess() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.failCount = 0
+}
+
+// ejected reports whether the backend is currently serving an ejection
+// imposed by RecordPassiveFailure.
+func (b *Backend) ejected() bool {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return !b.ejectedUntil.IsZero() && time.Now().Before(b.ejectedUntil)
+}
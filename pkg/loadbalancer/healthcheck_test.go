@@ -0,0 +1,85 @@
+package loadbalancer
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestBackend(t *testing.T, alive bool) *Backend {
+	t.Helper()
+	u, err := url.Parse("http://backend.example")
+	if err != nil {
+		t.Fatalf("parsing url: %v", err)
+	}
+	return &Backend{URL: u, Alive: alive}
+}
+
+func TestRecordActiveCheckUnhealthyThreshold(t *testing.T) {
+	b := newTestBackend(t, true)
+
+	b.recordActiveCheck(false, 2, 3)
+	if !b.IsAlive() {
+		t.Fatal("backend marked dead after 1 of 3 required failures")
+	}
+	b.recordActiveCheck(false, 2, 3)
+	if !b.IsAlive() {
+		t.Fatal("backend marked dead after 2 of 3 required failures")
+	}
+	b.recordActiveCheck(false, 2, 3)
+	if b.IsAlive() {
+		t.Fatal("backend still alive after 3 consecutive failures crossed the unhealthy threshold")
+	}
+}
+
+func TestRecordActiveCheckHealthyThreshold(t *testing.T) {
+	b := newTestBackend(t, false)
+
+	b.recordActiveCheck(true, 2, 3)
+	if b.IsAlive() {
+		t.Fatal("backend marked alive after 1 of 2 required successes")
+	}
+	b.recordActiveCheck(true, 2, 3)
+	if !b.IsAlive() {
+		t.Fatal("backend still dead after 2 consecutive successes crossed the healthy threshold")
+	}
+}
+
+func TestRecordActiveCheckSuccessResetsFailureStreak(t *testing.T) {
+	b := newTestBackend(t, true)
+
+	b.recordActiveCheck(false, 2, 3)
+	b.recordActiveCheck(false, 2, 3)
+	b.recordActiveCheck(true, 2, 3)
+	b.recordActiveCheck(false, 2, 3)
+	b.recordActiveCheck(false, 2, 3)
+	if !b.IsAlive() {
+		t.Fatal("backend marked dead even though the success reset the consecutive-failure streak")
+	}
+}
+
+func TestRecordPassiveFailureEjectsAfterMaxFails(t *testing.T) {
+	b := newTestBackend(t, true)
+
+	b.RecordPassiveFailure(3, time.Minute)
+	b.RecordPassiveFailure(3, time.Minute)
+	if b.ejected() {
+		t.Fatal("backend ejected before reaching MaxFails")
+	}
+	b.RecordPassiveFailure(3, time.Minute)
+	if !b.ejected() {
+		t.Fatal("backend not ejected after MaxFails consecutive passive failures")
+	}
+}
+
+func TestRecordPassiveSuccessResetsFailCount(t *testing.T) {
+	b := newTestBackend(t, true)
+
+	b.RecordPassiveFailure(3, time.Minute)
+	b.RecordPassiveFailure(3, time.Minute)
+	b.RecordPassiveSuccess()
+	b.RecordPassiveFailure(3, time.Minute)
+	if b.ejected() {
+		t.Fatal("backend ejected even though RecordPassiveSuccess reset the failure count")
+	}
+}
@@ -1,26 +1,82 @@
 package loadbalancer
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
-	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// ewmaAlpha is the base smoothing factor for Backend latency EWMA updates.
+// It is widened when a backend hasn't reported a sample in a while so a
+// long-idle backend's average reacts quickly to fresh data.
+const ewmaAlpha = 0.3
+
 type Backend struct {
-	URL          *url.URL
-	Alive        bool
-	mux          sync.RWMutex
-	ReverseProxy *httputil.ReverseProxy
+	URL                 *url.URL
+	Alive               bool
+	mux                 sync.RWMutex
+	NumberOfConnections int
+	// reverseProxy is swapped atomically rather than mutated in place: it's
+	// read unsynchronized by the stdlib inside ReverseProxy.ServeHTTP, so a
+	// live backend's proxy can only ever be replaced wholesale (e.g. by
+	// Reload picking up a new Transport), never patched field-by-field.
+	reverseProxy         atomic.Pointer[httputil.ReverseProxy]
+	ewma                 float64
+	ewmaUpdated          time.Time
+	consecutiveSuccesses int
+	consecutiveFailures  int
+	failCount            int
+	failWindowStart      time.Time
+	ejectedUntil         time.Time
+	inFlight             sync.WaitGroup
 }
 
 type LoadBalancer struct {
-	backends []*Backend
-	current  uint64
+	mu            sync.RWMutex
+	backends      []*Backend
+	strategy      Strategy
+	healthConfig  HealthCheckConfig
+	healthChecker *HealthChecker
+	retryConfig   RetryConfig
+	transport     *http.Transport
+}
+
+// Option configures a LoadBalancer at construction time.
+type Option func(*LoadBalancer)
+
+// WithStrategy selects the backend-picking algorithm. Defaults to
+// RoundRobin when not supplied.
+func WithStrategy(s Strategy) Option {
+	return func(lb *LoadBalancer) {
+		lb.strategy = s
+	}
+}
+
+// WithHealthCheckConfig overrides the default active/passive health-check
+// settings.
+func WithHealthCheckConfig(cfg HealthCheckConfig) Option {
+	return func(lb *LoadBalancer) {
+		lb.healthConfig = cfg
+	}
+}
+
+// WithTransport overrides the default shared *http.Transport used by every
+// backend's ReverseProxy. Build t with NewTransport to get tuned upstream
+// TLS and HTTP/2 support.
+func WithTransport(t *http.Transport) Option {
+	return func(lb *LoadBalancer) {
+		lb.transport = t
+	}
 }
 
 func (b *Backend) SetAlive(alive bool) {
@@ -36,84 +92,501 @@ func (b *Backend) IsAlive() (alive bool) {
 	return
 }
 
-func (lb *LoadBalancer) NextBackend() *Backend {
-	next := atomic.AddUint64(&lb.current, uint64(1)) % uint64(len(lb.backends))
+// Available reports whether the backend should receive traffic: it must be
+// alive per active health checks and not currently ejected by passive
+// failure tracking.
+func (b *Backend) Available() bool {
+	return b.IsAlive() && !b.ejected()
+}
+
+// ReverseProxy returns the backend's current *httputil.ReverseProxy. Safe to
+// call concurrently with Reload swapping in a new one; a request already in
+// flight keeps using whichever proxy it loaded here.
+func (b *Backend) ReverseProxy() *httputil.ReverseProxy {
+	return b.reverseProxy.Load()
+}
+
+// setReverseProxy atomically swaps in a freshly built proxy. It never
+// mutates the proxy already in use, so a concurrent ReverseProxy() caller
+// can't observe a partially-updated one.
+func (b *Backend) setReverseProxy(p *httputil.ReverseProxy) {
+	b.reverseProxy.Store(p)
+}
+
+// IncrementNumberOfConnections increments the active connection count.
+func (b *Backend) IncrementNumberOfConnections() {
+	b.mux.Lock()
+	b.NumberOfConnections++
+	n := b.NumberOfConnections
+	b.mux.Unlock()
+	b.inFlight.Add(1)
+	inFlightRequests.WithLabelValues(b.URL.String()).Set(float64(n))
+}
+
+// DecrementNumberOfConnections decrements the active connection count.
+func (b *Backend) DecrementNumberOfConnections() {
+	b.mux.Lock()
+	if b.NumberOfConnections > 0 {
+		b.NumberOfConnections--
+	}
+	n := b.NumberOfConnections
+	b.mux.Unlock()
+	b.inFlight.Done()
+	inFlightRequests.WithLabelValues(b.URL.String()).Set(float64(n))
+}
+
+// GetNumberOfConnections returns the current active connection count.
+func (b *Backend) GetNumberOfConnections() int {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return b.NumberOfConnections
+}
+
+// Drain blocks until every request routed to this backend has had its
+// response status written to the client. A response body that is still
+// being streamed at that point is not waited for. Only call this once the
+// backend has been taken out of rotation, e.g. after a config Reload drops
+// it.
+func (b *Backend) Drain() {
+	b.inFlight.Wait()
+}
+
+// RecordLatency folds a fresh response-time sample into the backend's EWMA,
+// used by the P2CEWMA strategy. ServeHTTP's samples run from the start of an
+// attempt until its response status is written; time spent streaming the
+// response body is not included.
+func (b *Backend) RecordLatency(d time.Duration) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	sample := float64(d.Milliseconds())
+	if b.ewmaUpdated.IsZero() {
+		b.ewma = sample
+	} else {
+		alpha := ewmaAlpha
+		if decay := time.Since(b.ewmaUpdated).Seconds() / 10; decay > alpha {
+			alpha = decay
+		}
+		if alpha > 1 {
+			alpha = 1
+		}
+		b.ewma = b.ewma*(1-alpha) + sample*alpha
+	}
+	b.ewmaUpdated = time.Now()
+}
+
+// GetEWMA returns the backend's current latency EWMA in milliseconds.
+func (b *Backend) GetEWMA() float64 {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return b.ewma
+}
+
+// StartHealthChecker builds and starts the active HealthChecker for this
+// load balancer's backends, using the configured (or default)
+// HealthCheckConfig. Reload keeps it in sync as backends come and go. The
+// caller is responsible for calling Stop on the returned checker during
+// shutdown.
+func (lb *LoadBalancer) StartHealthChecker() *HealthChecker {
+	lb.mu.Lock()
+	hc := NewHealthChecker(lb.healthConfig)
+	lb.healthChecker = hc
+	backends := lb.backends
+	lb.mu.Unlock()
+
+	hc.Start(backends)
+	return hc
+}
+
+// Reload atomically swaps this LoadBalancer's backend set, strategy,
+// health-check config, and shared transport to match cfg. Backends whose
+// URL is unchanged are kept as-is, so their health state and connection
+// counts survive the reload; backends for new URLs are created fresh;
+// backends that dropped out of cfg are removed from rotation immediately
+// and drained of in-flight requests in the background.
+func (lb *LoadBalancer) Reload(cfg *Config) error {
+	healthConfig, err := cfg.HealthCheckConfig()
+	if err != nil {
+		return err
+	}
+
+	strategy, err := cfg.StrategyFromName()
+	if err != nil {
+		return err
+	}
 
-	for i := range lb.backends {
-		idx := (int(next) + i) % len(lb.backends)
-		if lb.backends[idx].IsAlive() {
-			return lb.backends[idx]
+	retryConfig, err := cfg.RetryConfig()
+	if err != nil {
+		return err
+	}
+
+	transportConfig, err := cfg.TransportConfig()
+	if err != nil {
+		return err
+	}
+	transport, err := NewTransport(transportConfig)
+	if err != nil {
+		return err
+	}
+
+	lb.mu.Lock()
+	lb.transport = transport
+	existing := make(map[string]*Backend, len(lb.backends))
+	for _, b := range lb.backends {
+		existing[b.URL.String()] = b
+	}
+	lb.mu.Unlock()
+
+	next := make([]*Backend, 0, len(cfg.Backends))
+	seen := make(map[string]bool, len(cfg.Backends))
+	for _, raw := range cfg.Backends {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("parsing backend url %q: %w", raw, err)
+		}
+		seen[u.String()] = true
+
+		if b, ok := existing[u.String()]; ok {
+			b.setReverseProxy(lb.newReverseProxy(u, transport))
+			next = append(next, b)
+			continue
 		}
+		next = append(next, lb.newBackend(u, transport))
+	}
+
+	var removed []*Backend
+	for u, b := range existing {
+		if !seen[u] {
+			removed = append(removed, b)
+		}
+	}
+
+	lb.mu.Lock()
+	lb.backends = next
+	lb.strategy = strategy
+	lb.healthConfig = healthConfig
+	lb.retryConfig = retryConfig
+	hc := lb.healthChecker
+	lb.mu.Unlock()
+
+	if hc != nil {
+		for _, b := range removed {
+			hc.StopBackend(b)
+		}
+		hc.UpdateConfig(healthConfig)
+		hc.Start(next)
+	}
+
+	for _, b := range removed {
+		go func(b *Backend) {
+			b.Drain()
+			log.Printf("Backend %s drained and removed from rotation", b.URL)
+		}(b)
 	}
 
 	return nil
 }
 
-func IsBackendAlive(u *url.URL) bool {
-	timeout := 2 * time.Second
-	conn, err := net.DialTimeout("tcp", u.Host, timeout)
+// ServeHTTP picks a backend via the configured Strategy and proxies the
+// request to it, transparently retrying against a different backend (up
+// to RetryConfig.MaxRetries) when the attempt fails with a connection
+// error, timeout, or 502/503/504. The request body is buffered once so it
+// can be replayed across attempts, and the whole request (all attempts
+// combined) is bounded by RetryConfig.RequestTimeout. A client that
+// disconnects mid-request gets a 499 rather than burning retries on a
+// response nobody will read.
+//
+// The response is never buffered in memory: each attempt's ReverseProxy
+// streams straight through to the real client ResponseWriter, and a
+// retryable status is caught by ModifyResponse before any body bytes are
+// copied, so the common non-retried path (including large or slow/chunked
+// responses) pays no buffering cost.
+func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lb.mu.RLock()
+	backends := lb.backends
+	strategy := lb.strategy
+	healthConfig := lb.healthConfig
+	retryConfig := lb.retryConfig
+	lb.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), retryConfig.RequestTimeout)
+	defer cancel()
+
+	requestStart := time.Now()
+	var (
+		finalBackend    *Backend
+		upstreamLatency time.Duration
+		attempts        int
+		status          int
+	)
+	defer func() {
+		retries := attempts - 1
+		if retries < 0 {
+			retries = 0
+		}
+		logAccess(r, finalBackend, status, upstreamLatency, time.Since(requestStart), retries)
+	}()
+
+	body, err := bufferRequestBody(r, retryConfig.MaxBodyBytes)
 	if err != nil {
-		log.Printf("Site unreachable: %s", err)
-		return false
+		status = http.StatusRequestEntityTooLarge
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	tried := make(map[*Backend]bool)
+	var wrapped *responseWriter
+
+	for attempt := 0; attempt <= retryConfig.MaxRetries; attempt++ {
+		backend := strategy.Pick(r, excludeBackends(availableBackends(backends), tried))
+		if backend == nil {
+			wrapped = nil
+			break
+		}
+		attempts = attempt + 1
+		tried[backend] = true
+		if attempt > 0 {
+			retriesTotal.WithLabelValues(backend.URL.String()).Inc()
+		}
+
+		attemptReq := r.Clone(withAttempt(ctx, attempt, retryConfig.MaxRetries))
+		if body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+			attemptReq.ContentLength = int64(len(body))
+		}
+
+		backend.IncrementNumberOfConnections()
+		attemptStart := time.Now()
+		wrapped = &responseWriter{
+			ResponseWriter: w,
+			backend:        backend,
+			start:          attemptStart,
+			healthConfig:   healthConfig,
+		}
+		backend.ReverseProxy().ServeHTTP(wrapped, attemptReq)
+
+		finalBackend = backend
+		upstreamLatency = time.Since(attemptStart)
+
+		if wrapped.clientClosed {
+			status = 499
+			return
+		}
+
+		if wrapped.retryStatus != 0 {
+			status = wrapped.retryStatus
+			continue
+		}
+
+		status = wrapped.statusCode
+		break
+	}
+
+	if wrapped == nil {
+		status = http.StatusServiceUnavailable
+		http.Error(w, "Service Unavailable", status)
+		return
+	}
+
+	if wrapped.retryStatus != 0 {
+		// Retries exhausted on a retryable status: ModifyResponse closed
+		// that attempt's body before anything reached the client, so
+		// nothing has been written yet.
+		status = wrapped.retryStatus
+		http.Error(w, "Service Unavailable", status)
 	}
-	defer conn.Close()
-	return true
 }
 
-func (lb *LoadBalancer) HealthCheck() {
-	for _, backend := range lb.backends {
-		status := IsBackendAlive(backend.URL)
-		backend.SetAlive(status)
-		if status {
-			log.Printf("Backend %s is alive", backend.URL)
-		} else {
-			log.Printf("Backend %s is dead", backend.URL)
+// availableBackends filters out backends ejected by passive health
+// tracking before handing the set to the selection Strategy, which itself
+// only checks IsAlive.
+func availableBackends(backends []*Backend) []*Backend {
+	out := make([]*Backend, 0, len(backends))
+	for _, b := range backends {
+		if !b.ejected() {
+			out = append(out, b)
 		}
 	}
+	return out
 }
 
-func (lb *LoadBalancer) HealthCheckPeriodically(duration time.Duration) {
-	t := time.NewTicker(duration)
-	for {
-		select {
-		case <-t.C:
-			lb.HealthCheck()
+// responseWriter wraps the real http.ResponseWriter so the load balancer
+// can record connection, latency, and passive-failure bookkeeping at the
+// single point where a backend's response status is first written, while
+// still streaming
+// every Write straight through to the client. retryStatus is set instead
+// of writing through when the backend's ModifyResponse hook has flagged a
+// retryable status with a retry still available: ServeHTTP reads it to
+// fail over to the next backend without anything having reached the
+// client yet.
+type responseWriter struct {
+	http.ResponseWriter
+	backend      *Backend
+	start        time.Time
+	healthConfig HealthCheckConfig
+	statusCode   int
+	written      bool
+	clientClosed bool
+	retryStatus  int
+}
+
+// finish records the connection/latency/passive-failure bookkeeping for
+// one attempt against statusCode. It's called exactly once per attempt,
+// either from the first Write/WriteHeader on the common path or from
+// recordRetryableFailure when the attempt is being retried instead of
+// written through.
+func (rw *responseWriter) finish(statusCode int) {
+	rw.backend.DecrementNumberOfConnections()
+	latency := time.Since(rw.start)
+	rw.backend.RecordLatency(latency)
+
+	backendLabel := rw.backend.URL.String()
+	requestsTotal.WithLabelValues(backendLabel, strconv.Itoa(statusCode)).Inc()
+	requestDurationSeconds.WithLabelValues(backendLabel).Observe(latency.Seconds())
+
+	if rw.clientClosed {
+		// Not the backend's fault; don't let a client hang-up count
+		// against it.
+		return
+	}
+
+	if statusCode >= http.StatusInternalServerError {
+		rw.backend.RecordPassiveFailure(rw.healthConfig.MaxFails, rw.healthConfig.FailTimeout)
+	} else {
+		rw.backend.RecordPassiveSuccess()
+	}
+}
+
+func (rw *responseWriter) Write(data []byte) (int, error) {
+	if !rw.written {
+		rw.written = true
+		if rw.statusCode == 0 {
+			rw.statusCode = http.StatusOK
 		}
+		defer rw.finish(rw.statusCode)
 	}
+	return rw.ResponseWriter.Write(data)
 }
 
-func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	backend := lb.NextBackend()
-	if backend == nil {
-		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if !rw.written {
+		rw.written = true
+		rw.statusCode = statusCode
+		defer rw.finish(statusCode)
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// recordRetryableFailure records the same bookkeeping as finish, but
+// without writing anything to the real client: it's called when the
+// backend's response (or a RoundTrip error) is retryable and a retry is
+// still available, so ServeHTTP is about to try the next backend instead
+// of surfacing this attempt's status.
+func (rw *responseWriter) recordRetryableFailure(statusCode int) {
+	if rw.written {
 		return
 	}
-	backend.ReverseProxy.ServeHTTP(w, r)
+	rw.written = true
+	rw.statusCode = statusCode
+	rw.retryStatus = statusCode
+	rw.finish(statusCode)
 }
 
-func NewLoadBalancer(serverURLs []string) (*LoadBalancer, error) {
-	var backends []*Backend
-	
-	for _, serverURL := range serverURLs {
-		url, err := url.Parse(serverURL)
+// newReverseProxy builds the *httputil.ReverseProxy for u against transport.
+// Its hooks keep retries from ever buffering or writing through a response
+// that might still fail over:
+//
+//   - ModifyResponse runs before any response body is copied. If the
+//     status is retryable and the request still has a retry left, it
+//     closes the body and returns a retryableStatusError instead of
+//     letting ReverseProxy stream it to the client.
+//   - ErrorHandler distinguishes a client-side cancel (no point retrying,
+//     no point penalizing the backend), a retryableStatusError from
+//     ModifyResponse, and a real RoundTrip failure (connection refused,
+//     timeout, ...), writing a response to the client only once no retry
+//     is left.
+func (lb *LoadBalancer) newReverseProxy(u *url.URL, transport *http.Transport) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	proxy.Transport = transport
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		attempt := AttemptFromContext(resp.Request.Context())
+		maxRetries := maxRetriesFromContext(resp.Request.Context())
+		if attempt < maxRetries && resp.StatusCode >= http.StatusInternalServerError && isRetryableStatus(resp.StatusCode) {
+			return &retryableStatusError{status: resp.StatusCode}
+		}
+		return nil
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		attempt := AttemptFromContext(r.Context())
+		maxRetries := maxRetriesFromContext(r.Context())
+		rw, _ := w.(*responseWriter)
+
+		if errors.Is(err, context.Canceled) {
+			log.Printf("Client closed request (attempt %d) to backend %s: %v", attempt, u, err)
+			if rw != nil {
+				rw.clientClosed = true
+			}
+			http.Error(w, "Client Closed Request", 499)
+			return
+		}
+
+		var rse *retryableStatusError
+		if errors.As(err, &rse) {
+			if rw != nil {
+				rw.recordRetryableFailure(rse.status)
+			}
+			return
+		}
+
+		log.Printf("Error (attempt %d) proxying to backend %s: %v", attempt, u, err)
+		upstreamErrorsTotal.WithLabelValues(u.String()).Inc()
+		if rw != nil && attempt < maxRetries {
+			rw.recordRetryableFailure(http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	}
+	return proxy
+}
+
+// newBackend constructs a Backend for u with a ReverseProxy built against
+// transport.
+func (lb *LoadBalancer) newBackend(u *url.URL, transport *http.Transport) *Backend {
+	backend := &Backend{
+		URL:   u,
+		Alive: true,
+	}
+	backend.setReverseProxy(lb.newReverseProxy(u, transport))
+	backendHealthy.WithLabelValues(u.String()).Set(1)
+	return backend
+}
+
+func NewLoadBalancer(serverURLs []string, opts ...Option) (*LoadBalancer, error) {
+	lb := &LoadBalancer{
+		strategy:     NewRoundRobin(),
+		healthConfig: DefaultHealthCheckConfig(),
+		retryConfig:  DefaultRetryConfig(),
+	}
+
+	for _, opt := range opts {
+		opt(lb)
+	}
+
+	if lb.transport == nil {
+		transport, err := NewTransport(DefaultTransportConfig())
 		if err != nil {
 			return nil, err
 		}
+		lb.transport = transport
+	}
 
-		proxy := httputil.NewSingleHostReverseProxy(url)
-		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-			log.Printf("Error: %v", err)
-			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	for _, serverURL := range serverURLs {
+		u, err := url.Parse(serverURL)
+		if err != nil {
+			return nil, err
 		}
-
-		backends = append(backends, &Backend{
-			URL:          url,
-			Alive:        true,
-			ReverseProxy: proxy,
-		})
+		lb.backends = append(lb.backends, lb.newBackend(u, lb.transport))
 	}
-	
-	return &LoadBalancer{
-		backends: backends,
-	}, nil
-}
\ No newline at end of file
+
+	return lb, nil
+}
@@ -0,0 +1,106 @@
+package loadbalancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLoadBalancer(t *testing.T, retryConfig RetryConfig, serverURLs ...string) *LoadBalancer {
+	t.Helper()
+	lb, err := NewLoadBalancer(serverURLs, WithRetryConfig(retryConfig))
+	if err != nil {
+		t.Fatalf("NewLoadBalancer() error = %v", err)
+	}
+	return lb
+}
+
+func TestServeHTTPFailsOverToNextBackend(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "ok")
+	}))
+	defer healthy.Close()
+
+	lb := newTestLoadBalancer(t, RetryConfig{MaxRetries: 1, RequestTimeout: 2 * time.Second, MaxBodyBytes: 1 << 20}, failing.URL, healthy.URL)
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Body.String() != "ok" {
+		t.Fatalf("body = %q, want %q", rec.Body.String(), "ok")
+	}
+}
+
+func TestServeHTTPExhaustsRetriesAndReturns503(t *testing.T) {
+	var hits int
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	lb := newTestLoadBalancer(t, RetryConfig{MaxRetries: 1, RequestTimeout: 2 * time.Second, MaxBodyBytes: 1 << 20}, failing.URL)
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	// Retries exclude already-tried backends, and there's only one
+	// backend here, so it's hit exactly once even with MaxRetries: 1.
+	if hits != 1 {
+		t.Fatalf("backend hit %d times, want 1", hits)
+	}
+}
+
+func TestServeHTTPStreamsResponseBodyThrough(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("httptest server's ResponseWriter doesn't support flushing")
+		}
+		for i := 0; i < 3; i++ {
+			io.WriteString(w, "chunk")
+			flusher.Flush()
+		}
+	}))
+	defer backend.Close()
+
+	lb := newTestLoadBalancer(t, DefaultRetryConfig(), backend.URL)
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if want := "chunkchunkchunk"; rec.Body.String() != want {
+		t.Fatalf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
+
+func TestServeHTTPNoBackendAvailable(t *testing.T) {
+	lb, err := NewLoadBalancer(nil, WithRetryConfig(RetryConfig{MaxRetries: 2, RequestTimeout: time.Second, MaxBodyBytes: 1 << 20}))
+	if err != nil {
+		t.Fatalf("NewLoadBalancer() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,79 @@
+package loadbalancer
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadbalancer_requests_total",
+			Help: "Total requests proxied to a backend, by backend and response status.",
+		},
+		[]string{"backend", "status"},
+	)
+
+	inFlightRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "loadbalancer_in_flight_requests",
+			Help: "Current number of in-flight requests, by backend.",
+		},
+		[]string{"backend"},
+	)
+
+	requestDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "loadbalancer_request_duration_seconds",
+			Help:    "Upstream request latency in seconds, by backend.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"backend"},
+	)
+
+	upstreamErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadbalancer_upstream_errors_total",
+			Help: "Total proxy errors (connection refused, timeout, etc.), by backend.",
+		},
+		[]string{"backend"},
+	)
+
+	backendHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "loadbalancer_backend_healthy",
+			Help: "1 if the backend currently passes active health checks, 0 otherwise.",
+		},
+		[]string{"backend"},
+	)
+
+	retriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadbalancer_retries_total",
+			Help: "Total retry attempts made against a backend after a prior attempt failed.",
+		},
+		[]string{"backend"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		requestsTotal,
+		inFlightRequests,
+		requestDurationSeconds,
+		upstreamErrorsTotal,
+		backendHealthy,
+		retriesTotal,
+	)
+}
+
+// StartMetricsServer runs a dedicated HTTP server exposing Prometheus
+// metrics at /metrics on addr. It blocks until the server stops, so callers
+// typically run it in its own goroutine.
+func StartMetricsServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return http.ListenAndServe(addr, mux)
+}
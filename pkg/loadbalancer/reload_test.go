@@ -0,0 +1,142 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestReloadKeepsExistingBackendAddsAndRemoves(t *testing.T) {
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer a.Close()
+	b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer b.Close()
+	c := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer c.Close()
+
+	lb := newTestLoadBalancer(t, DefaultRetryConfig(), a.URL, b.URL)
+
+	lb.mu.RLock()
+	oldA := lb.backends[0]
+	lb.mu.RUnlock()
+
+	if err := lb.Reload(&Config{Backends: []string{a.URL, c.URL}}); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	lb.mu.RLock()
+	backends := lb.backends
+	lb.mu.RUnlock()
+
+	if len(backends) != 2 {
+		t.Fatalf("len(backends) = %d, want 2", len(backends))
+	}
+
+	cURL, err := url.Parse(c.URL)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", c.URL, err)
+	}
+	bURL, err := url.Parse(b.URL)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", b.URL, err)
+	}
+
+	var gotA, gotC *Backend
+	for _, bb := range backends {
+		switch bb.URL.String() {
+		case oldA.URL.String():
+			gotA = bb
+		case cURL.String():
+			gotC = bb
+		}
+	}
+
+	if gotA != oldA {
+		t.Fatal("Reload rebuilt a backend whose URL was unchanged; want the same *Backend kept")
+	}
+	if gotC == nil {
+		t.Fatal("Reload did not add the new backend")
+	}
+
+	for _, bb := range backends {
+		if bb.URL.String() == bURL.String() {
+			t.Fatal("Reload kept a backend that was dropped from the config")
+		}
+	}
+}
+
+func TestStartHealthCheckerWatchesBackends(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	lb := newTestLoadBalancer(t, DefaultRetryConfig(), backend.URL)
+
+	hc := lb.StartHealthChecker()
+	defer hc.Stop()
+
+	lb.mu.RLock()
+	b := lb.backends[0]
+	lb.mu.RUnlock()
+
+	hc.mux.Lock()
+	_, watched := hc.stops[b]
+	hc.mux.Unlock()
+
+	if !watched {
+		t.Fatal("StartHealthChecker did not start watching the load balancer's backend")
+	}
+}
+
+// TestReloadConcurrentWithServeHTTP runs Reload against an unchanged backend
+// set concurrently with live ServeHTTP traffic. Before Backend.ReverseProxy
+// became an atomic.Pointer, Reload mutated b.ReverseProxy.Transport in place
+// while ServeHTTP's ReverseProxy.ServeHTTP read the same field unsynchronized,
+// so this reproduces under go test -race.
+func TestReloadConcurrentWithServeHTTP(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	lb := newTestLoadBalancer(t, DefaultRetryConfig(), backend.URL)
+	cfg := &Config{Backends: []string{backend.URL}}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(stop)
+		for i := 0; i < 200; i++ {
+			rec := httptest.NewRecorder()
+			lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := lb.Reload(cfg); err != nil {
+				t.Errorf("Reload() error = %v", err)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+}
@@ -0,0 +1,138 @@
+package loadbalancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// errRequestBodyTooLarge is returned by bufferRequestBody when a request's
+// body exceeds RetryConfig.MaxBodyBytes.
+var errRequestBodyTooLarge = errors.New("request body exceeds the retry buffer limit")
+
+// RetryConfig controls how ServeHTTP retries a request against another
+// backend after a failed upstream attempt.
+type RetryConfig struct {
+	// MaxRetries is the number of additional backends to try after the
+	// first attempt fails. A value of 0 disables retries.
+	MaxRetries int
+	// RequestTimeout bounds the entire request, across all attempts.
+	RequestTimeout time.Duration
+	// MaxBodyBytes caps how much of the request body is buffered so it
+	// can be replayed against a different backend; requests with a
+	// larger body are rejected rather than retried.
+	MaxBodyBytes int64
+}
+
+// DefaultRetryConfig returns the retry settings used when a LoadBalancer is
+// constructed without an explicit RetryConfig.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:     2,
+		RequestTimeout: 10 * time.Second,
+		MaxBodyBytes:   10 << 20, // 10MB
+	}
+}
+
+// WithRetryConfig overrides the default retry/failover settings.
+func WithRetryConfig(cfg RetryConfig) Option {
+	return func(lb *LoadBalancer) {
+		lb.retryConfig = cfg
+	}
+}
+
+type retryContextKey struct{}
+
+// retryContext carries the current attempt number (0-indexed) and the
+// request's MaxRetries, so the ReverseProxy's ModifyResponse/ErrorHandler
+// hooks know whether a failed attempt still has a retry left without
+// reaching back into the LoadBalancer.
+type retryContext struct {
+	attempt    int
+	maxRetries int
+}
+
+// withAttempt tags ctx with the current attempt number and the request's
+// retry budget, so error handlers and logs can tell which try they're
+// looking at and whether another one is coming.
+func withAttempt(ctx context.Context, attempt, maxRetries int) context.Context {
+	return context.WithValue(ctx, retryContextKey{}, retryContext{attempt: attempt, maxRetries: maxRetries})
+}
+
+// AttemptFromContext returns the attempt number stashed by withAttempt, or
+// 0 if the request's context carries none.
+func AttemptFromContext(ctx context.Context) int {
+	if rc, ok := ctx.Value(retryContextKey{}).(retryContext); ok {
+		return rc.attempt
+	}
+	return 0
+}
+
+// maxRetriesFromContext returns the MaxRetries stashed by withAttempt, or 0
+// if the request's context carries none.
+func maxRetriesFromContext(ctx context.Context) int {
+	if rc, ok := ctx.Value(retryContextKey{}).(retryContext); ok {
+		return rc.maxRetries
+	}
+	return 0
+}
+
+// isRetryableStatus reports whether a response status indicates an
+// upstream failure worth retrying on another backend.
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// excludeBackends returns the backends not present in tried, preserving
+// order.
+func excludeBackends(backends []*Backend, tried map[*Backend]bool) []*Backend {
+	if len(tried) == 0 {
+		return backends
+	}
+	out := make([]*Backend, 0, len(backends))
+	for _, b := range backends {
+		if !tried[b] {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// bufferRequestBody reads r.Body into memory, up to maxBytes+1, so it can
+// be replayed on a retry. It returns nil, nil for requests with no body.
+func bufferRequestBody(r *http.Request, maxBytes int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	defer r.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, errRequestBodyTooLarge
+	}
+	return data, nil
+}
+
+// retryableStatusError is returned by a backend's ModifyResponse hook when
+// the upstream answered with a retryable status and the request still has
+// a retry left. It carries the status code through to ErrorHandler so the
+// attempt can be recorded without ever copying the (possibly large,
+// possibly streaming) response body to the real client.
+type retryableStatusError struct {
+	status int
+}
+
+func (e *retryableStatusError) Error() string {
+	return fmt.Sprintf("retryable upstream status %d", e.status)
+}
@@ -0,0 +1,85 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNotFound, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusGatewayTimeout, true},
+	}
+
+	for _, tt := range tests {
+		if got := isRetryableStatus(tt.status); got != tt.want {
+			t.Errorf("isRetryableStatus(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestExcludeBackends(t *testing.T) {
+	a := mustBackend(t, "http://a")
+	b := mustBackend(t, "http://b")
+	c := mustBackend(t, "http://c")
+	backends := []*Backend{a, b, c}
+
+	got := excludeBackends(backends, map[*Backend]bool{b: true})
+	if len(got) != 2 || got[0] != a || got[1] != c {
+		t.Fatalf("excludeBackends() = %v, want [a c]", got)
+	}
+
+	if got := excludeBackends(backends, nil); len(got) != 3 {
+		t.Fatalf("excludeBackends() with no tried backends = %v, want all 3", got)
+	}
+}
+
+func TestBufferRequestBodyWithinLimit(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+
+	data, err := bufferRequestBody(r, 10)
+	if err != nil {
+		t.Fatalf("bufferRequestBody() error = %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("bufferRequestBody() = %q, want %q", data, "hello")
+	}
+}
+
+func TestBufferRequestBodyOverLimit(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("this body is too long"))
+
+	_, err := bufferRequestBody(r, 4)
+	if err != errRequestBodyTooLarge {
+		t.Fatalf("bufferRequestBody() error = %v, want errRequestBodyTooLarge", err)
+	}
+}
+
+func TestBufferRequestBodyNoBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	data, err := bufferRequestBody(r, 10)
+	if err != nil || data != nil {
+		t.Fatalf("bufferRequestBody() = (%v, %v), want (nil, nil)", data, err)
+	}
+}
+
+func TestAttemptAndMaxRetriesFromContext(t *testing.T) {
+	ctx := withAttempt(httptest.NewRequest(http.MethodGet, "/", nil).Context(), 1, 2)
+
+	if got := AttemptFromContext(ctx); got != 1 {
+		t.Errorf("AttemptFromContext() = %d, want 1", got)
+	}
+	if got := maxRetriesFromContext(ctx); got != 2 {
+		t.Errorf("maxRetriesFromContext() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,153 @@
+package loadbalancer
+
+import (
+	"hash/crc32"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Strategy picks a backend to serve a given request from the set of
+// configured backends. Implementations must be safe for concurrent use and
+// should skip backends that are not alive.
+type Strategy interface {
+	Pick(r *http.Request, backends []*Backend) *Backend
+}
+
+// aliveBackends returns the subset of backends currently marked alive.
+func aliveBackends(backends []*Backend) []*Backend {
+	alive := make([]*Backend, 0, len(backends))
+	for _, b := range backends {
+		if b.IsAlive() {
+			alive = append(alive, b)
+		}
+	}
+	return alive
+}
+
+// RoundRobin cycles through backends in order, skipping dead ones.
+type RoundRobin struct {
+	current uint64
+}
+
+// NewRoundRobin returns a Strategy that distributes requests evenly across
+// backends in order.
+func NewRoundRobin() *RoundRobin {
+	return &RoundRobin{}
+}
+
+func (s *RoundRobin) Pick(r *http.Request, backends []*Backend) *Backend {
+	if len(backends) == 0 {
+		return nil
+	}
+
+	next := atomic.AddUint64(&s.current, 1) % uint64(len(backends))
+	for i := range backends {
+		idx := (int(next) + i) % len(backends)
+		if backends[idx].IsAlive() {
+			return backends[idx]
+		}
+	}
+
+	return nil
+}
+
+// LeastConnections picks the alive backend with the fewest active
+// connections.
+type LeastConnections struct{}
+
+func (LeastConnections) Pick(r *http.Request, backends []*Backend) *Backend {
+	var chosen *Backend
+	minConnections := -1
+
+	for _, b := range backends {
+		if !b.IsAlive() {
+			continue
+		}
+
+		connections := b.GetNumberOfConnections()
+		if minConnections == -1 || connections < minConnections {
+			minConnections = connections
+			chosen = b
+		}
+	}
+
+	return chosen
+}
+
+// Random picks a uniformly random alive backend.
+type Random struct{}
+
+func (Random) Pick(r *http.Request, backends []*Backend) *Backend {
+	alive := aliveBackends(backends)
+	if len(alive) == 0 {
+		return nil
+	}
+	return alive[rand.Intn(len(alive))]
+}
+
+// IPHash deterministically maps a client to the same backend for the
+// lifetime of the backend set, giving session affinity keyed on the
+// client's IP (or an X-Forwarded-For header when present behind a proxy).
+type IPHash struct{}
+
+func (IPHash) Pick(r *http.Request, backends []*Backend) *Backend {
+	alive := aliveBackends(backends)
+	if len(alive) == 0 {
+		return nil
+	}
+
+	h := crc32.ChecksumIEEE([]byte(clientKey(r)))
+	return alive[int(h)%len(alive)]
+}
+
+// clientKey extracts the key used to hash a request to a backend.
+func clientKey(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		return strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// P2CEWMA implements power-of-two-choices selection weighted by each
+// backend's exponentially-weighted moving average response latency: it
+// samples two random alive backends and picks the one with the lower EWMA,
+// breaking ties on active connection count. This avoids the herd behavior
+// of always picking the single fastest backend while still steering traffic
+// away from slow ones.
+type P2CEWMA struct{}
+
+func (P2CEWMA) Pick(r *http.Request, backends []*Backend) *Backend {
+	alive := aliveBackends(backends)
+	if len(alive) == 0 {
+		return nil
+	}
+	if len(alive) == 1 {
+		return alive[0]
+	}
+
+	i := rand.Intn(len(alive))
+	j := rand.Intn(len(alive) - 1)
+	if j >= i {
+		j++
+	}
+	a, b := alive[i], alive[j]
+
+	switch ea, eb := a.GetEWMA(), b.GetEWMA(); {
+	case ea < eb:
+		return a
+	case eb < ea:
+		return b
+	case a.GetNumberOfConnections() <= b.GetNumberOfConnections():
+		return a
+	default:
+		return b
+	}
+}
@@ -0,0 +1,103 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func mustBackend(t *testing.T, rawURL string) *Backend {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", rawURL, err)
+	}
+	return &Backend{URL: u, Alive: true}
+}
+
+func TestP2CEWMAPrefersLowerEWMA(t *testing.T) {
+	fast := mustBackend(t, "http://fast")
+	slow := mustBackend(t, "http://slow")
+	fast.ewma = 10
+	slow.ewma = 100
+
+	s := P2CEWMA{}
+	for i := 0; i < 20; i++ {
+		if got := s.Pick(nil, []*Backend{fast, slow}); got != fast {
+			t.Fatalf("Pick() = %v, want the lower-EWMA backend", got.URL)
+		}
+	}
+}
+
+func TestP2CEWMABreaksTiesOnConnections(t *testing.T) {
+	busy := mustBackend(t, "http://busy")
+	idle := mustBackend(t, "http://idle")
+	busy.NumberOfConnections = 5
+	idle.NumberOfConnections = 0
+	// Equal EWMA (both default to zero) forces the connection-count
+	// tie-break.
+
+	s := P2CEWMA{}
+	for i := 0; i < 20; i++ {
+		if got := s.Pick(nil, []*Backend{busy, idle}); got != idle {
+			t.Fatalf("Pick() = %v, want the backend with fewer connections", got.URL)
+		}
+	}
+}
+
+func TestP2CEWMASkipsDeadBackends(t *testing.T) {
+	dead := mustBackend(t, "http://dead")
+	dead.Alive = false
+	alive := mustBackend(t, "http://alive")
+
+	s := P2CEWMA{}
+	for i := 0; i < 20; i++ {
+		if got := s.Pick(nil, []*Backend{dead, alive}); got != alive {
+			t.Fatalf("Pick() = %v, want the only alive backend", got.URL)
+		}
+	}
+}
+
+func TestIPHashIsStableForSameClient(t *testing.T) {
+	backends := []*Backend{
+		mustBackend(t, "http://a"),
+		mustBackend(t, "http://b"),
+		mustBackend(t, "http://c"),
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "203.0.113.7:54321"
+
+	s := IPHash{}
+	first := s.Pick(r, backends)
+	if first == nil {
+		t.Fatal("Pick() = nil, want a backend")
+	}
+	for i := 0; i < 20; i++ {
+		if got := s.Pick(r, backends); got != first {
+			t.Fatalf("Pick() = %v on call %d, want stable %v", got.URL, i, first.URL)
+		}
+	}
+}
+
+func TestIPHashUsesForwardedForOverRemoteAddr(t *testing.T) {
+	backends := []*Backend{
+		mustBackend(t, "http://a"),
+		mustBackend(t, "http://b"),
+		mustBackend(t, "http://c"),
+	}
+
+	r1 := httptest.NewRequest(http.MethodGet, "/", nil)
+	r1.RemoteAddr = "198.51.100.1:1111"
+	r1.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+
+	r2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	r2.RemoteAddr = "198.51.100.2:2222"
+	r2.Header.Set("X-Forwarded-For", "203.0.113.9")
+
+	s := IPHash{}
+	if got1, got2 := s.Pick(r1, backends), s.Pick(r2, backends); got1 != got2 {
+		t.Fatalf("requests sharing X-Forwarded-For client %q landed on different backends: %v vs %v", "203.0.113.9", got1.URL, got2.URL)
+	}
+}
@@ -0,0 +1,39 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ConfigureTLS prepares server to terminate TLS according to c.TLS. It
+// returns ok=false when c.TLS is unset, so the caller can fall back to
+// plain HTTP. When AutocertHosts is set, server.TLSConfig is populated with
+// an autocert manager and certFile/keyFile are returned empty, since
+// ListenAndServeTLS accepts empty paths when TLSConfig already supplies
+// certificates via GetCertificate.
+func (c *Config) ConfigureTLS(server *http.Server) (certFile, keyFile string, ok bool, err error) {
+	if c.TLS == nil {
+		return "", "", false, nil
+	}
+
+	if len(c.TLS.AutocertHosts) > 0 {
+		cacheDir := c.TLS.AutocertCacheDir
+		if cacheDir == "" {
+			cacheDir = "."
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(c.TLS.AutocertHosts...),
+			Cache:      autocert.DirCache(cacheDir),
+		}
+		server.TLSConfig = manager.TLSConfig()
+		return "", "", true, nil
+	}
+
+	if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+		return "", "", false, fmt.Errorf("tls: either autocert_hosts or both cert_file and key_file must be set")
+	}
+	return c.TLS.CertFile, c.TLS.KeyFile, true, nil
+}
@@ -0,0 +1,73 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestConfigureTLSNotSet(t *testing.T) {
+	cfg := &Config{}
+	server := &http.Server{}
+
+	certFile, keyFile, ok, err := cfg.ConfigureTLS(server)
+	if err != nil {
+		t.Fatalf("ConfigureTLS() error = %v", err)
+	}
+	if ok {
+		t.Fatal("ConfigureTLS() ok = true, want false when TLS is unset")
+	}
+	if certFile != "" || keyFile != "" {
+		t.Fatalf("ConfigureTLS() = (%q, %q), want empty paths", certFile, keyFile)
+	}
+}
+
+func TestConfigureTLSCertAndKeyFile(t *testing.T) {
+	cfg := &Config{TLS: &TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}}
+	server := &http.Server{}
+
+	certFile, keyFile, ok, err := cfg.ConfigureTLS(server)
+	if err != nil {
+		t.Fatalf("ConfigureTLS() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("ConfigureTLS() ok = false, want true")
+	}
+	if certFile != "cert.pem" || keyFile != "key.pem" {
+		t.Fatalf("ConfigureTLS() = (%q, %q), want (cert.pem, key.pem)", certFile, keyFile)
+	}
+	if server.TLSConfig != nil {
+		t.Fatal("ConfigureTLS() set server.TLSConfig for a plain cert/key pair; want it left to ListenAndServeTLS")
+	}
+}
+
+func TestConfigureTLSRejectsIncompleteCertPair(t *testing.T) {
+	cfg := &Config{TLS: &TLSConfig{CertFile: "cert.pem"}}
+	server := &http.Server{}
+
+	_, _, ok, err := cfg.ConfigureTLS(server)
+	if err == nil {
+		t.Fatal("ConfigureTLS() error = nil, want an error when only CertFile is set")
+	}
+	if ok {
+		t.Fatal("ConfigureTLS() ok = true, want false on error")
+	}
+}
+
+func TestConfigureTLSAutocert(t *testing.T) {
+	cfg := &Config{TLS: &TLSConfig{AutocertHosts: []string{"example.com"}, AutocertCacheDir: t.TempDir()}}
+	server := &http.Server{}
+
+	certFile, keyFile, ok, err := cfg.ConfigureTLS(server)
+	if err != nil {
+		t.Fatalf("ConfigureTLS() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("ConfigureTLS() ok = false, want true")
+	}
+	if certFile != "" || keyFile != "" {
+		t.Fatalf("ConfigureTLS() = (%q, %q), want empty paths since certs come from TLSConfig.GetCertificate", certFile, keyFile)
+	}
+	if server.TLSConfig == nil {
+		t.Fatal("ConfigureTLS() left server.TLSConfig nil, want an autocert-backed TLSConfig")
+	}
+}
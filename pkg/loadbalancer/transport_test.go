@@ -0,0 +1,81 @@
+package loadbalancer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTransportAppliesConfig(t *testing.T) {
+	transport, err := NewTransport(TransportConfig{
+		MaxIdleConnsPerHost: 42,
+		InsecureSkipVerify:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewTransport() error = %v", err)
+	}
+
+	if transport.MaxIdleConnsPerHost != 42 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 42", transport.MaxIdleConnsPerHost)
+	}
+	if !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("TLSClientConfig.InsecureSkipVerify = false, want true")
+	}
+	if transport.TLSClientConfig.ServerName != "" {
+		t.Errorf("TLSClientConfig.ServerName = %q, want empty so Go derives SNI per backend", transport.TLSClientConfig.ServerName)
+	}
+}
+
+func TestNewTransportLoadsCACertFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, []byte(testCACertPEM), 0o644); err != nil {
+		t.Fatalf("writing CA cert: %v", err)
+	}
+
+	transport, err := NewTransport(TransportConfig{CACertFile: path})
+	if err != nil {
+		t.Fatalf("NewTransport() error = %v", err)
+	}
+	if transport.TLSClientConfig.RootCAs == nil {
+		t.Fatal("RootCAs = nil, want a pool including the CA cert file")
+	}
+}
+
+func TestNewTransportRejectsBadCACertFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatalf("writing CA cert: %v", err)
+	}
+
+	if _, err := NewTransport(TransportConfig{CACertFile: path}); err == nil {
+		t.Fatal("NewTransport() error = nil, want an error for a CA cert file with no certificates")
+	}
+}
+
+func TestNewTransportRejectsMissingCACertFile(t *testing.T) {
+	if _, err := NewTransport(TransportConfig{CACertFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Fatal("NewTransport() error = nil, want an error for a missing CA cert file")
+	}
+}
+
+// testCACertPEM is a self-signed certificate, valid only as PEM-decodable
+// input for exercising the CACertFile loading path above.
+const testCACertPEM = `-----BEGIN CERTIFICATE-----
+MIIC/zCCAeegAwIBAgIUBJDDfDVuzb+7aQxp0ojm6Wp3JvkwDQYJKoZIhvcNAQEL
+BQAwDzENMAsGA1UEAwwEdGVzdDAeFw0yNjA3MjYyMzIzNDNaFw0zNjA3MjMyMzIz
+NDNaMA8xDTALBgNVBAMMBHRlc3QwggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAwggEK
+AoIBAQDDcvUYgL3g+m+iSalr2pOxbDnIx41KK2SuhiC7h+GxWvteEmCyDdIyhnM+
+MRGMSVOZReBbb/0TuUMlhZJdlkePytdfe4Fu9daCqGDhza3aVu7vq/Ch41VJQoL3
+XLwUB8ZvfWmKiMzkS9aabt65IU6PQFsqFaNiabl/qCkUzHGJQEcNlC0nYdGk5RgE
+eRr5HA8AIn7SIL/Fly0KEQs2seDtN7Z83TCrHtQbtYE2ImYQPFhVrrleaAwfrzOs
+3uxAMDU0aEkDpWReKgBQ+P4p81zT/PO0TN7rh87kZ/4Gp5ZVteErb4ou58j0DqM5
+F4pXDv/gZpk+8oCLLdB2roYw7+Y9AgMBAAGjUzBRMB0GA1UdDgQWBBQtyaR2JFsF
+lYTOJP+YRg0thON93DAfBgNVHSMEGDAWgBQtyaR2JFsFlYTOJP+YRg0thON93DAP
+BgNVHRMBAf8EBTADAQH/MA0GCSqGSIb3DQEBCwUAA4IBAQCfuJSrh7lENkyr+/6m
+p+VAUq1WZYzz1S1X3frgbjOUM63bdoVuQt6XtRM1EX+n58pDbWPmkUnMHJ0ZayTf
+WGQE+NaDTEbLxU2Bx9wdRK1eHTOdEx0xSLM726DL9mlQUgArZIynVm1efM9BknbB
+Aa9KgI0Yvjbos93Ge/RXgje5p3uwhYwbFZgdjtgC8N9Fgt0qDSWC5MRKBi0b7mTQ
+8kYclV3JtOnlMsPsuTITSm1qv/3l7iDdFy4OjCJgYu7i8TJQdDewmOHyLwOLIgnb
+iqYThfau181PltPw6B/TqVr8I7wSGMitfNT5+7V/juCnebxhaOIe6O/XiJrTiUCC
+oNbK
+-----END CERTIFICATE-----`